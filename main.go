@@ -2,17 +2,28 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
-	"golang.org/x/oauth2/google"
+	"cloud.google.com/go/bigquery"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
+
+	"github.com/rhpds/cluster-status-updater-sheet/pkg/auth"
+	"github.com/rhpds/cluster-status-updater-sheet/pkg/poller"
+	"github.com/rhpds/cluster-status-updater-sheet/pkg/schema"
+	sheetshelper "github.com/rhpds/cluster-status-updater-sheet/pkg/sheets"
+	"github.com/rhpds/cluster-status-updater-sheet/pkg/sink"
 )
 
 // Main structs to map the JSON response
@@ -28,14 +39,33 @@ type StatusResponse struct {
 }
 
 func main() {
+	historyRetention := flag.Int("history-retention", 0, "days of history rows to keep in the history tab; 0 disables trimming")
+	workers := flag.Int("workers", 0, "number of workers for concurrent per-cluster polling; 0 keeps the legacy single aggregate poll")
+	pollInitialInterval := flag.Duration("poll-initial-interval", 2*time.Second, "initial backoff interval between per-cluster poll attempts")
+	pollMultiplier := flag.Float64("poll-multiplier", 2, "backoff multiplier applied between per-cluster poll attempts")
+	pollMaxInterval := flag.Duration("poll-max-interval", 30*time.Second, "maximum backoff interval between per-cluster poll attempts")
+	pollMaxElapsed := flag.Duration("poll-max-elapsed", 2*time.Minute, "maximum total time to retry a single cluster before giving up on it")
+	pollClusterTimeout := flag.Duration("poll-cluster-timeout", 45*time.Second, "context timeout for polling a single cluster")
+	sinkSpec := flag.String("sink", "sheets", "comma-separated output sinks to write to: sheets, csv:<path>, json:<path>, bq:<dataset.table>")
+	schemaPath := flag.String("schema", "", "path to a YAML column schema; when set, replaces the default alphabetical flatten with declared columns, types, and array handling")
+	allowExtra := flag.Bool("allow-extra", false, "with --schema, append flattened keys it doesn't declare, sorted alphabetically, instead of dropping them")
+	flag.Parse()
+
+	ctx := context.Background()
+
 	// 1. Load environment variables
 	apiRoute := os.Getenv("API_ROUTE")
 	adminToken := os.Getenv("ADMIN_TOKEN")
 	spreadsheetID := os.Getenv("SPREADSHEET_ID")
 	credsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
 
-	if apiRoute == "" || adminToken == "" || spreadsheetID == "" || credsFile == "" {
-		log.Fatal("Environment variables API_ROUTE, ADMIN_TOKEN, SPREADSHEET_ID, and GOOGLE_APPLICATION_CREDENTIALS must be set")
+	if apiRoute == "" || adminToken == "" {
+		log.Fatal("Environment variables API_ROUTE and ADMIN_TOKEN must be set")
+	}
+
+	wantsSheets := sinkNames(*sinkSpec)["sheets"]
+	if wantsSheets && (spreadsheetID == "" || credsFile == "") {
+		log.Fatal("Environment variables SPREADSHEET_ID and GOOGLE_APPLICATION_CREDENTIALS must be set when the sheets sink is enabled")
 	}
 
 	// 2. Authenticate with the restricted endpoint
@@ -51,86 +81,319 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to retrieve cluster status: %v", err)
 	}
+	clusters := clusterData.Body.Clusters
+
+	// 3b. In concurrent mode, re-poll each cluster individually through a
+	// worker pool instead of trusting the single aggregate response, so one
+	// slow cluster can't hold up the rest and transient 5xxs get retried.
+	if *workers > 0 {
+		names := make([]string, 0, len(clusters))
+		for name := range clusters {
+			names = append(names, name)
+		}
+
+		log.Printf("Polling %d clusters individually with %d workers...", len(names), *workers)
+		refreshed, pollErrs := poller.PollClusters(ctx, apiRoute, token, names, poller.Options{
+			Workers:           *workers,
+			InitialInterval:   *pollInitialInterval,
+			Multiplier:        *pollMultiplier,
+			MaxInterval:       *pollMaxInterval,
+			MaxElapsedTime:    *pollMaxElapsed,
+			PerClusterTimeout: *pollClusterTimeout,
+		})
+		for _, pollErr := range pollErrs {
+			log.Printf("Warning: %v", pollErr)
+		}
+		if len(refreshed) == 0 {
+			log.Fatal("All clusters failed to poll individually")
+		}
+		clusters = refreshed
+	}
 
-	// 4. Dynamically flatten and prepare data for Google Sheets
-	log.Println("Flattening data and generating dynamic header...")
+	// 4. Flatten each cluster record into a row, either against a declared
+	// --schema (stable column order, typed values, explicit array handling)
+	// or, by default, the legacy alphabetical-order string flatten.
+	log.Println("Flattening data and generating header...")
 
-	headerMap := make(map[string]bool)
+	var header []string
 	var flattenedClusters []map[string]string
+	var typedRows []map[string]interface{} // only populated in --schema mode
+
+	if *schemaPath != "" {
+		sch, err := schema.Load(*schemaPath)
+		if err != nil {
+			log.Fatalf("Failed to load schema: %v", err)
+		}
+		header = sch.Header()
+
+		legacyFlattened := make(map[string]map[string]string, len(clusters))
+		observedKeys := make(map[string]bool)
+		if *allowExtra {
+			for clusterName, clusterInterface := range clusters {
+				flat := flatten(clusterInterface, "")
+				flat["cluster_name"] = clusterName
+				legacyFlattened[clusterName] = flat
+				for key := range flat {
+					observedKeys[key] = true
+				}
+			}
+			header = append(header, schema.ExtraColumns(header, observedKeys)...)
+		}
+
+		for clusterName, clusterInterface := range clusters {
+			record, _ := clusterInterface.(map[string]interface{})
+			augmented := make(map[string]interface{}, len(record)+1)
+			for k, v := range record {
+				augmented[k] = v
+			}
+			augmented["cluster_name"] = clusterName
+
+			rows, err := sch.Flatten(augmented)
+			if err != nil {
+				log.Fatalf("Failed to apply schema to cluster %q: %v", clusterName, err)
+			}
+			for _, row := range rows {
+				if *allowExtra {
+					for key, value := range legacyFlattened[clusterName] {
+						if _, declared := row[key]; !declared {
+							row[key] = value
+						}
+					}
+				}
+				typedRows = append(typedRows, row)
+				flattenedClusters = append(flattenedClusters, stringifyRow(header, row))
+			}
+		}
+	} else {
+		headerMap := make(map[string]bool)
 
-	for clusterName, clusterInterface := range clusterData.Body.Clusters {
-		flattenedData := flatten(clusterInterface, "")
+		for clusterName, clusterInterface := range clusters {
+			flattenedData := flatten(clusterInterface, "")
 
-		// Add the cluster name as a field
-		flattenedData["cluster_name"] = clusterName
+			// Add the cluster name as a field
+			flattenedData["cluster_name"] = clusterName
 
-		for key := range flattenedData {
-			headerMap[key] = true
+			for key := range flattenedData {
+				headerMap[key] = true
+			}
+			flattenedClusters = append(flattenedClusters, flattenedData)
 		}
-		flattenedClusters = append(flattenedClusters, flattenedData)
-	}
 
-	var header []string
-	for key := range headerMap {
-		header = append(header, key)
+		for key := range headerMap {
+			header = append(header, key)
+		}
+		sort.Strings(header)
 	}
-	sort.Strings(header)
 
-	headerRow := make([]interface{}, len(header))
-	for i, v := range header {
-		headerRow[i] = v
+	// 5. Set up a Sheets client if any configured sink needs one, then write
+	// the flattened clusters to every configured sink.
+	var srv *sheets.Service
+	if wantsSheets {
+		authMode := auth.Mode(os.Getenv("GOOGLE_AUTH_MODE"))
+		tokenFile := os.Getenv("GOOGLE_AUTH_TOKEN_FILE")
+		if tokenFile == "" {
+			tokenFile = "token.json"
+		}
+		client, err := auth.NewClient(ctx, authMode, credsFile, tokenFile, sheets.SpreadsheetsScope)
+		if err != nil {
+			log.Fatalf("Unable to build Google auth client: %v", err)
+		}
+		srv, err = sheets.NewService(ctx, option.WithHTTPClient(client))
+		if err != nil {
+			log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		}
 	}
 
-	rows := [][]interface{}{headerRow}
+	sinks, err := buildSinks(ctx, *sinkSpec, srv, spreadsheetID)
+	if err != nil {
+		log.Fatalf("Invalid --sink: %v", err)
+	}
 
-	for _, clusterData := range flattenedClusters {
-		var row []interface{}
-		for _, key := range header {
-			value, ok := clusterData[key]
-			if !ok {
-				row = append(row, "")
-			} else {
-				row = append(row, value)
+	// In --schema mode, write full_data directly with typed cells rather
+	// than through the generic, string-only Sink interface, so numbers,
+	// booleans, and timestamps reach the sheet as real types.
+	if *schemaPath != "" && wantsSheets {
+		log.Println("Writing typed cells to full_data...")
+		table := make([][]interface{}, 0, len(typedRows)+1)
+		headerRow := make([]interface{}, len(header))
+		for i, h := range header {
+			headerRow[i] = h
+		}
+		table = append(table, headerRow)
+		for _, row := range typedRows {
+			record := make([]interface{}, len(header))
+			for i, col := range header {
+				record[i] = row[col]
 			}
+			table = append(table, record)
 		}
-		rows = append(rows, row)
-	}
 
-	// 5. Update the Google Sheet
-	log.Println("Updating Google Sheet...")
-	ctx := context.Background()
+		writer := sheetshelper.NewSheetWriter(srv, spreadsheetID, "full_data")
+		if err := writer.BatchAppendCells(ctx, "full_data!A1:Z", table); err != nil {
+			log.Fatalf("Failed to update sheet: %v", err)
+		}
+		sinks = nonSheetsSinks(sinks)
+	}
 
-	// Set up Google Sheets client
-	b, err := os.ReadFile(credsFile)
-	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+	log.Printf("Writing %d rows to %d sink(s)...", len(flattenedClusters), len(sinks))
+	for _, sk := range sinks {
+		if err := sk.Write(ctx, header, flattenedClusters); err != nil {
+			log.Fatalf("Failed to write to sink: %v", err)
+		}
 	}
-	config, err := google.JWTConfigFromJSON(b, sheets.SpreadsheetsScope)
-	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+
+	// 6. Append a timestamped snapshot of this run to the history tab so
+	// trend analysis (node counts, version drift, health over time) doesn't
+	// require an external time-series store. This only applies to the
+	// sheets sink, since history is a spreadsheet-specific feature.
+	if wantsSheets {
+		log.Println("Appending history snapshot...")
+		pollTime := time.Now().UTC()
+		historyWriter := sheetshelper.NewSheetWriter(srv, spreadsheetID, "history")
+
+		historyHeader, err := historyWriter.AlignHeader(ctx, "history!A1:1", append([]string{"timestamp", "cluster_name", "hash"}, header...))
+		if err != nil {
+			log.Fatalf("Failed to align history header: %v", err)
+		}
+		historyCol := make(map[string]int, len(historyHeader))
+		for i, col := range historyHeader {
+			historyCol[col] = i
+		}
+
+		historyRows := make([][]interface{}, len(flattenedClusters))
+		for i, clusterData := range flattenedClusters {
+			row := make([]interface{}, len(historyHeader))
+			for j := range row {
+				row[j] = ""
+			}
+			row[historyCol["timestamp"]] = pollTime.Format(time.RFC3339)
+			row[historyCol["hash"]] = recordHash(clusterData)
+			for key, value := range clusterData {
+				if idx, ok := historyCol[key]; ok {
+					row[idx] = value
+				}
+			}
+			historyRows[i] = row
+		}
+
+		if err := historyWriter.AppendRows(ctx, "history!A1:Z", historyRows); err != nil {
+			log.Fatalf("Failed to append history rows: %v", err)
+		}
+
+		if *historyRetention > 0 {
+			if idx, ok := historyCol["timestamp"]; ok {
+				cutoff := pollTime.AddDate(0, 0, -*historyRetention)
+				col := sheetshelper.ColumnLetter(idx)
+				timestampRange := fmt.Sprintf("history!%s:%s", col, col)
+				err := historyWriter.TrimRows(ctx, timestampRange, 1, func(v string) bool {
+					t, err := time.Parse(time.RFC3339, v)
+					return err == nil && t.Before(cutoff)
+				})
+				if err != nil {
+					log.Fatalf("Failed to trim history rows: %v", err)
+				}
+			}
+		}
 	}
-	client := config.Client(ctx)
-	srv, err := sheets.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+
+	log.Println("Successfully updated cluster status report!")
+}
+
+// sinkNames splits a --sink flag value (e.g. "sheets,csv:/out.csv") into the
+// set of sink names it names, ignoring any ":arg" suffix.
+func sinkNames(spec string) map[string]bool {
+	names := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if name != "" {
+			names[name] = true
+		}
 	}
+	return names
+}
 
-	// Write data to sheet
-	valueRange := &sheets.ValueRange{
-		Values: rows,
+// buildSinks parses a --sink flag value into the Sink implementations it
+// names, so a single run can write its flattened clusters to several
+// destinations (e.g. "sheets,bq:dataset.table,csv:/path/out.csv").
+func buildSinks(ctx context.Context, spec string, srv *sheets.Service, spreadsheetID string) ([]sink.Sink, error) {
+	var sinks []sink.Sink
+	for _, part := range strings.Split(spec, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), ":")
+		switch name {
+		case "sheets":
+			sinks = append(sinks, &sink.SheetsSink{
+				Writer:     sheetshelper.NewSheetWriter(srv, spreadsheetID, "full_data"),
+				ClearRange: "full_data!A1:Z",
+			})
+		case "csv":
+			if arg == "" {
+				return nil, fmt.Errorf(`sink "csv" requires a path, e.g. csv:/path/out.csv`)
+			}
+			sinks = append(sinks, &sink.CSVSink{Path: arg})
+		case "json":
+			if arg == "" {
+				return nil, fmt.Errorf(`sink "json" requires a path, e.g. json:/path/out.jsonl`)
+			}
+			sinks = append(sinks, &sink.JSONLSink{Path: arg})
+		case "bq":
+			dataset, table, ok := strings.Cut(arg, ".")
+			if !ok {
+				return nil, fmt.Errorf(`sink "bq" requires dataset.table, e.g. bq:mydataset.clusters`)
+			}
+			project := os.Getenv("BIGQUERY_PROJECT_ID")
+			if project == "" {
+				return nil, fmt.Errorf("BIGQUERY_PROJECT_ID must be set to use the bq sink")
+			}
+			client, err := bigquery.NewClient(ctx, project)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create BigQuery client: %w", err)
+			}
+			sinks = append(sinks, &sink.BigQuerySink{Client: client, Dataset: dataset, Table: table})
+		default:
+			return nil, fmt.Errorf("unknown sink %q", name)
+		}
 	}
+	return sinks, nil
+}
 
-	_, err = srv.Spreadsheets.Values.Clear(spreadsheetID, "full_data!A1:Z", &sheets.ClearValuesRequest{}).Do()
-	if err != nil {
-		log.Fatalf("Failed to clear sheet: %v", err)
+// nonSheetsSinks drops any *sink.SheetsSink from sinks, used in --schema mode
+// once full_data has already been written directly with typed cells.
+func nonSheetsSinks(sinks []sink.Sink) []sink.Sink {
+	var out []sink.Sink
+	for _, sk := range sinks {
+		if _, isSheets := sk.(*sink.SheetsSink); isSheets {
+			continue
+		}
+		out = append(out, sk)
 	}
+	return out
+}
 
-	_, err = srv.Spreadsheets.Values.Update(spreadsheetID, "full_data!A1", valueRange).ValueInputOption("USER_ENTERED").Do()
-	if err != nil {
-		log.Fatalf("Failed to update sheet: %v", err)
+// stringifyRow formats a schema-typed row as the map[string]string the
+// generic Sink interface expects, for sinks that don't consume typed cells.
+func stringifyRow(header []string, row map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(header))
+	for _, col := range header {
+		out[col] = formatCellValue(row[col])
 	}
+	return out
+}
 
-	log.Println("Successfully updated Google Sheet!")
+func formatCellValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
 }
 
 // flatten recursively flattens a nested map and collects all key-value pairs.
@@ -171,6 +434,22 @@ func flatten(jsonMap interface{}, prefix string) map[string]string {
 	return flattened
 }
 
+// recordHash returns a stable hash of a flattened cluster record, used as a
+// history row's identity key independent of map iteration order.
+func recordHash(record map[string]string) string {
+	keys := make([]string, 0, len(record))
+	for k := range record {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, record[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func getAccessToken(apiRoute, adminToken string) (string, error) {
 	req, err := http.NewRequest("GET", apiRoute+"/api/v1/login", nil)
 	if err != nil {
@@ -0,0 +1,120 @@
+// Package auth builds an authorized *http.Client for the Google APIs client
+// libraries, supporting either a service-account JSON key or an OAuth
+// installed-app flow with a cached, auto-refreshing token. This lets users
+// who can't provision a service account in their GCP org still run under
+// their own identity.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Mode selects how NewClient authenticates with Google.
+type Mode string
+
+const (
+	// ModeServiceAccount reads a service-account JWT credentials file. This
+	// is the default when Mode is empty.
+	ModeServiceAccount Mode = "service_account"
+	// ModeOAuth runs the OAuth installed-app flow on first use and caches
+	// the resulting token for subsequent runs.
+	ModeOAuth Mode = "oauth"
+)
+
+// NewClient returns an *http.Client authorized for scopes.
+//
+// In ModeServiceAccount, credsFile is a service-account JSON key.
+// In ModeOAuth, credsFile is an OAuth client secret ("credentials.json")
+// downloaded from the Google Cloud console, and tokenFile is where the
+// resulting token is cached and refreshed across runs.
+func NewClient(ctx context.Context, mode Mode, credsFile, tokenFile string, scopes ...string) (*http.Client, error) {
+	b, err := os.ReadFile(credsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials file: %w", err)
+	}
+
+	switch mode {
+	case ModeOAuth:
+		return oauthClient(ctx, b, tokenFile, scopes...)
+	case "", ModeServiceAccount:
+		return serviceAccountClient(ctx, b, scopes...)
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", mode)
+	}
+}
+
+func serviceAccountClient(ctx context.Context, credsJSON []byte, scopes ...string) (*http.Client, error) {
+	config, err := google.JWTConfigFromJSON(credsJSON, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse service account credentials: %w", err)
+	}
+	return config.Client(ctx), nil
+}
+
+func oauthClient(ctx context.Context, credsJSON []byte, tokenFile string, scopes ...string) (*http.Client, error) {
+	config, err := google.ConfigFromJSON(credsJSON, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse oauth client credentials: %w", err)
+	}
+
+	token, err := tokenFromFile(tokenFile)
+	if err != nil {
+		token, err = tokenFromWeb(config)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveToken(tokenFile, token); err != nil {
+			return nil, err
+		}
+	}
+	// config.Client wraps token in a TokenSource that refreshes it
+	// transparently using the refresh token, so callers never see expiry.
+	return config.Client(ctx, token), nil
+}
+
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	token := &oauth2.Token{}
+	if err := json.NewDecoder(f).Decode(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func tokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser, then paste the authorization code:\n%v\n", authURL)
+
+	var code string
+	if _, err := fmt.Scan(&code); err != nil {
+		return nil, fmt.Errorf("unable to read authorization code: %w", err)
+	}
+
+	token, err := config.Exchange(context.Background(), code)
+	if err != nil {
+		return nil, fmt.Errorf("unable to exchange authorization code for token: %w", err)
+	}
+	return token, nil
+}
+
+func saveToken(file string, token *oauth2.Token) error {
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to cache oauth token: %w", err)
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(token)
+}
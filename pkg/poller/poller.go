@@ -0,0 +1,128 @@
+// Package poller polls cluster status endpoints individually across a
+// worker pool, so that as the fleet grows one slow cluster can't block the
+// whole report and a transient 5xx doesn't fail the entire job.
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Options configures concurrent per-cluster polling.
+type Options struct {
+	Workers           int
+	InitialInterval   time.Duration
+	Multiplier        float64
+	MaxInterval       time.Duration
+	MaxElapsedTime    time.Duration
+	PerClusterTimeout time.Duration
+}
+
+type clusterStatusResponse struct {
+	Status string      `json:"status"`
+	Body   interface{} `json:"body"`
+}
+
+// PollClusters polls apiRoute for each of clusterNames individually, spread
+// across a pool of opts.Workers goroutines. Each cluster gets its own
+// exponential backoff retry loop and context timeout. It returns the data
+// for every cluster that eventually succeeded, plus one error per cluster
+// that didn't, so that successful clusters can still make it into the
+// report.
+func PollClusters(ctx context.Context, apiRoute, token string, clusterNames []string, opts Options) (map[string]interface{}, []error) {
+	jobs := make(chan string)
+	results := make(map[string]interface{}, len(clusterNames))
+	var errs []error
+	var mu sync.Mutex
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				data, err := pollCluster(ctx, apiRoute, token, name, opts)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("cluster %q: %w", name, err))
+				} else {
+					results[name] = data
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, name := range clusterNames {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, errs
+}
+
+// pollCluster polls a single cluster's status endpoint with exponential
+// backoff until it reports success, a non-retryable error occurs, or
+// opts.PerClusterTimeout elapses.
+func pollCluster(ctx context.Context, apiRoute, token, name string, opts Options) (interface{}, error) {
+	clusterCtx, cancel := context.WithTimeout(ctx, opts.PerClusterTimeout)
+	defer cancel()
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = opts.InitialInterval
+	b.Multiplier = opts.Multiplier
+	b.MaxInterval = opts.MaxInterval
+	b.MaxElapsedTime = opts.MaxElapsedTime
+
+	client := &http.Client{Timeout: opts.PerClusterTimeout}
+
+	var status clusterStatusResponse
+	operation := func() error {
+		req, err := http.NewRequestWithContext(clusterCtx, http.MethodGet,
+			fmt.Sprintf("%s/api/v1/ocp-shared-clusters/status?cluster=%s", apiRoute, name), nil)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		req.Header.Add("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("transient status %s", resp.Status)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return backoff.Permanent(fmt.Errorf("status %s", resp.Status))
+		}
+
+		status = clusterStatusResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			return backoff.Permanent(err)
+		}
+		if status.Status != "success" {
+			return fmt.Errorf("status %q, not yet success", status.Status)
+		}
+		return nil
+	}
+
+	if err := backoff.Retry(operation, backoff.WithContext(b, clusterCtx)); err != nil {
+		return nil, err
+	}
+	return status.Body, nil
+}
@@ -0,0 +1,260 @@
+// Package schema loads a declarative YAML column schema describing, for
+// each output column, its name, its JSONPath-like source within a flattened
+// cluster record, its type, and how an array along that path is reduced to
+// cell value(s). It replaces the previous random-order, string-only
+// flattening with a stable, typed, human-configured column set.
+package schema
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Type names a column's target Go type after extraction.
+type Type string
+
+const (
+	TypeString    Type = "string"
+	TypeNumber    Type = "number"
+	TypeBool      Type = "bool"
+	TypeDuration  Type = "duration"
+	TypeTimestamp Type = "timestamp"
+)
+
+// Column describes one declared output column.
+type Column struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	Type Type   `yaml:"type"`
+	// Array controls how a value found to be a JSON array is reduced:
+	// "" or "first" (default) takes the first element, "count" takes the
+	// array length, "join:<sep>" joins stringified elements with sep, and
+	// "explode" expands one output row per element.
+	Array string `yaml:"array"`
+}
+
+// Schema is a declarative, ordered column configuration loaded from YAML.
+type Schema struct {
+	Columns []Column `yaml:"columns"`
+}
+
+// Load reads and parses a schema YAML file.
+func Load(path string) (*Schema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file %q: %w", path, err)
+	}
+	var s Schema
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse schema file %q: %w", path, err)
+	}
+	for i, col := range s.Columns {
+		if col.Name == "" {
+			return nil, fmt.Errorf("schema column %d is missing a name", i)
+		}
+	}
+	return &s, nil
+}
+
+// Header returns the declared column names, in configuration order.
+func (s *Schema) Header() []string {
+	header := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		header[i] = c.Name
+	}
+	return header
+}
+
+// Flatten evaluates every declared column against record and returns the
+// row(s) it produces, keyed by column name. A column with Array: "explode"
+// multiplies the result into one row per element; values from other
+// columns are repeated across all resulting rows.
+func (s *Schema) Flatten(record interface{}) ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{{}}
+
+	for _, col := range s.Columns {
+		raw := lookup(record, col.Path)
+		mode, sep := parseArrayMode(col.Array)
+
+		arr, isArray := raw.([]interface{})
+		if !isArray {
+			typed, err := convert(col.Type, raw)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			for _, row := range rows {
+				row[col.Name] = typed
+			}
+			continue
+		}
+
+		switch mode {
+		case "count":
+			for _, row := range rows {
+				row[col.Name] = float64(len(arr))
+			}
+		case "join":
+			parts := make([]string, len(arr))
+			for i, v := range arr {
+				parts[i] = fmt.Sprintf("%v", v)
+			}
+			for _, row := range rows {
+				row[col.Name] = strings.Join(parts, sep)
+			}
+		case "explode":
+			expanded, err := explodeRows(rows, col.Name, col.Type, arr)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			rows = expanded
+		default: // "first"
+			var first interface{}
+			if len(arr) > 0 {
+				first = arr[0]
+			}
+			typed, err := convert(col.Type, first)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			for _, row := range rows {
+				row[col.Name] = typed
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+func explodeRows(rows []map[string]interface{}, name string, colType Type, arr []interface{}) ([]map[string]interface{}, error) {
+	if len(arr) == 0 {
+		for _, row := range rows {
+			row[name] = nil
+		}
+		return rows, nil
+	}
+
+	out := make([]map[string]interface{}, 0, len(rows)*len(arr))
+	for _, row := range rows {
+		for _, elem := range arr {
+			typed, err := convert(colType, elem)
+			if err != nil {
+				return nil, err
+			}
+			clone := make(map[string]interface{}, len(row)+1)
+			for k, v := range row {
+				clone[k] = v
+			}
+			clone[name] = typed
+			out = append(out, clone)
+		}
+	}
+	return out, nil
+}
+
+// parseArrayMode splits a Column.Array spec into its mode and, for "join",
+// the separator to join with.
+func parseArrayMode(spec string) (mode, sep string) {
+	if spec == "" {
+		return "first", ""
+	}
+	if rest, ok := strings.CutPrefix(spec, "join:"); ok {
+		return "join", rest
+	}
+	return spec, ""
+}
+
+// lookup walks value along a dotted, JSONPath-like path (e.g.
+// ".status.version.desired") and returns whatever it finds, or nil if any
+// segment is missing.
+func lookup(value interface{}, path string) interface{} {
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return value
+	}
+
+	cur := value
+	for _, seg := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[seg]
+	}
+	return cur
+}
+
+// convert coerces a raw JSON value into the Go type Type names.
+func convert(t Type, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t {
+	case TypeNumber:
+		switch val := v.(type) {
+		case float64:
+			return val, nil
+		case string:
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to number: %w", val, err)
+			}
+			return f, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %v (%T) to number", v, v)
+		}
+	case TypeBool:
+		switch val := v.(type) {
+		case bool:
+			return val, nil
+		case string:
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to bool: %w", val, err)
+			}
+			return b, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %v (%T) to bool", v, v)
+		}
+	case TypeDuration:
+		d, err := time.ParseDuration(fmt.Sprintf("%v", v))
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %v to duration: %w", v, err)
+		}
+		return d.String(), nil
+	case TypeTimestamp:
+		s := fmt.Sprintf("%v", v)
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to timestamp: %w", s, err)
+		}
+		return t, nil
+	default: // TypeString, or unset
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// ExtraColumns returns the keys in observed that aren't already part of
+// header, sorted alphabetically, for appending to the end of a schema's
+// declared columns under --allow-extra.
+func ExtraColumns(header []string, observed map[string]bool) []string {
+	known := make(map[string]bool, len(header))
+	for _, h := range header {
+		known[h] = true
+	}
+
+	var extra []string
+	for k := range observed {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	return extra
+}
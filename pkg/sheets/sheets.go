@@ -0,0 +1,295 @@
+// Package sheets provides small, reusable helpers around the generated
+// google.golang.org/api/sheets/v4 client: creating tabs, reading ranges, and
+// writing rows as typed cells instead of USER_ENTERED strings so that
+// downstream pivot tables, conditional formatting, and charts can rely on
+// real number, boolean, and date types.
+package sheets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// sheetsEpoch is the date Google Sheets treats as serial day zero.
+var sheetsEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// SheetWriter writes typed, batched rows to a single tab of a spreadsheet,
+// creating the tab first if it does not already exist.
+type SheetWriter struct {
+	Service       *sheets.Service
+	SpreadsheetID string
+	SheetTitle    string
+}
+
+// NewSheetWriter returns a SheetWriter for the given tab of spreadsheetID.
+func NewSheetWriter(srv *sheets.Service, spreadsheetID, sheetTitle string) *SheetWriter {
+	return &SheetWriter{Service: srv, SpreadsheetID: spreadsheetID, SheetTitle: sheetTitle}
+}
+
+// AddNewSheet creates a tab named title on spreadsheetID. It is safe to call
+// when the tab already exists; the API's "already exists" error is swallowed.
+func AddNewSheet(ctx context.Context, srv *sheets.Service, spreadsheetID, title string) error {
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AddSheet: &sheets.AddSheetRequest{
+					Properties: &sheets.SheetProperties{Title: title},
+				},
+			},
+		},
+	}).Context(ctx).Do()
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return fmt.Errorf("failed to add sheet %q: %w", title, err)
+	}
+	return nil
+}
+
+// ReadSheetValuesRange reads the given A1 range from spreadsheetID.
+func ReadSheetValuesRange(ctx context.Context, srv *sheets.Service, spreadsheetID, a1Range string) ([][]interface{}, error) {
+	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, a1Range).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read range %q: %w", a1Range, err)
+	}
+	return resp.Values, nil
+}
+
+// ValuesToCellData converts a row of Go values (string, float64, bool,
+// time.Time) into typed *sheets.CellData entries with the appropriate
+// UserEnteredValue field set, instead of coercing everything to a string.
+func ValuesToCellData(values []interface{}) []*sheets.CellData {
+	cells := make([]*sheets.CellData, len(values))
+	for i, v := range values {
+		cells[i] = valueToCellData(v)
+	}
+	return cells
+}
+
+func valueToCellData(v interface{}) *sheets.CellData {
+	switch val := v.(type) {
+	case nil:
+		return &sheets.CellData{}
+	case string:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &val}}
+	case float64:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &val}}
+	case int:
+		f := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &f}}
+	case bool:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{BoolValue: &val}}
+	case time.Time:
+		serial := val.Sub(sheetsEpoch).Hours() / 24
+		return &sheets.CellData{
+			UserEnteredValue: &sheets.ExtendedValue{NumberValue: &serial},
+			UserEnteredFormat: &sheets.CellFormat{
+				NumberFormat: &sheets.NumberFormat{Type: "DATE_TIME", Pattern: "yyyy-mm-dd hh:mm:ss"},
+			},
+		}
+	default:
+		s := fmt.Sprintf("%v", val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &s}}
+	}
+}
+
+// BatchAppendCells creates the writer's tab if needed, clears clearRange
+// (when non-empty), and writes rows as typed cells in a single appendCells
+// batch request.
+func (w *SheetWriter) BatchAppendCells(ctx context.Context, clearRange string, rows [][]interface{}) error {
+	if err := AddNewSheet(ctx, w.Service, w.SpreadsheetID, w.SheetTitle); err != nil {
+		return err
+	}
+
+	if clearRange != "" {
+		if _, err := w.Service.Spreadsheets.Values.Clear(w.SpreadsheetID, clearRange, &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to clear range %q: %w", clearRange, err)
+		}
+	}
+
+	sheetID, err := w.sheetID(ctx)
+	if err != nil {
+		return err
+	}
+
+	rowData := make([]*sheets.RowData, len(rows))
+	for i, row := range rows {
+		rowData[i] = &sheets.RowData{Values: ValuesToCellData(row)}
+	}
+
+	_, err = w.Service.Spreadsheets.BatchUpdate(w.SpreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AppendCells: &sheets.AppendCellsRequest{
+					SheetId: sheetID,
+					Rows:    rowData,
+					Fields:  "userEnteredValue,userEnteredFormat.numberFormat",
+				},
+			},
+		},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to append cells to %q: %w", w.SheetTitle, err)
+	}
+	return nil
+}
+
+// sheetID looks up the numeric sheet ID for the writer's tab, required by
+// batchUpdate requests that address a tab by ID rather than by title.
+func (w *SheetWriter) sheetID(ctx context.Context) (int64, error) {
+	spreadsheet, err := w.Service.Spreadsheets.Get(w.SpreadsheetID).Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up sheet %q: %w", w.SheetTitle, err)
+	}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties.Title == w.SheetTitle {
+			return sheet.Properties.SheetId, nil
+		}
+	}
+	return 0, fmt.Errorf("sheet %q not found after creation", w.SheetTitle)
+}
+
+// AppendRows creates the writer's tab if needed and appends rows to the end
+// of appendRange using the Values.Append API (USER_ENTERED/INSERT_ROWS), for
+// append-only logs such as a history tab where earlier rows must be left
+// untouched.
+func (w *SheetWriter) AppendRows(ctx context.Context, appendRange string, rows [][]interface{}) error {
+	if err := AddNewSheet(ctx, w.Service, w.SpreadsheetID, w.SheetTitle); err != nil {
+		return err
+	}
+
+	_, err := w.Service.Spreadsheets.Values.Append(w.SpreadsheetID, appendRange, &sheets.ValueRange{Values: rows}).
+		ValueInputOption("USER_ENTERED").
+		InsertDataOption("INSERT_ROWS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return fmt.Errorf("failed to append rows to %q: %w", w.SheetTitle, err)
+	}
+	return nil
+}
+
+// AlignHeader ensures the writer's tab has a header row at headerRange
+// covering every column in wantColumns. Columns already present keep their
+// existing position so rows written by earlier runs stay aligned; any column
+// in wantColumns that the sheet doesn't have yet is appended to the end. It
+// returns the full column order now in effect.
+func (w *SheetWriter) AlignHeader(ctx context.Context, headerRange string, wantColumns []string) ([]string, error) {
+	if err := AddNewSheet(ctx, w.Service, w.SpreadsheetID, w.SheetTitle); err != nil {
+		return nil, err
+	}
+
+	existing, err := ReadSheetValuesRange(ctx, w.Service, w.SpreadsheetID, headerRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+	seen := make(map[string]bool)
+	if len(existing) > 0 {
+		for _, v := range existing[0] {
+			col := fmt.Sprintf("%v", v)
+			header = append(header, col)
+			seen[col] = true
+		}
+	}
+
+	extended := false
+	for _, col := range wantColumns {
+		if !seen[col] {
+			header = append(header, col)
+			seen[col] = true
+			extended = true
+		}
+	}
+
+	if !extended && len(existing) > 0 {
+		return header, nil
+	}
+
+	headerRow := make([]interface{}, len(header))
+	for i, v := range header {
+		headerRow[i] = v
+	}
+	_, err = w.Service.Spreadsheets.Values.Update(w.SpreadsheetID, headerRange, &sheets.ValueRange{Values: [][]interface{}{headerRow}}).
+		ValueInputOption("USER_ENTERED").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write header for %q: %w", w.SheetTitle, err)
+	}
+	return header, nil
+}
+
+// TrimRows reads columnRange (a single column, including any header rows)
+// and deletes every row at index >= firstDataRow (0-indexed) whose cell
+// value matches shouldDelete, via one batchUpdate request containing a
+// DeleteDimensionRequest per contiguous run of matched rows.
+func (w *SheetWriter) TrimRows(ctx context.Context, columnRange string, firstDataRow int, shouldDelete func(string) bool) error {
+	values, err := ReadSheetValuesRange(ctx, w.Service, w.SpreadsheetID, columnRange)
+	if err != nil {
+		return err
+	}
+
+	var toDelete []int64
+	for i := firstDataRow; i < len(values); i++ {
+		if len(values[i]) == 0 {
+			continue
+		}
+		if shouldDelete(fmt.Sprintf("%v", values[i][0])) {
+			toDelete = append(toDelete, int64(i))
+		}
+	}
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	sheetID, err := w.sheetID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Walk from the bottom so each DeleteDimensionRequest's row indices stay
+	// valid regardless of the other requests in the same batch.
+	var requests []*sheets.Request
+	for end := len(toDelete) - 1; end >= 0; {
+		start := end
+		for start > 0 && toDelete[start-1] == toDelete[start]-1 {
+			start--
+		}
+		requests = append(requests, &sheets.Request{
+			DeleteDimension: &sheets.DeleteDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    sheetID,
+					Dimension:  "ROWS",
+					StartIndex: toDelete[start],
+					EndIndex:   toDelete[end] + 1,
+				},
+			},
+		})
+		end = start - 1
+	}
+
+	_, err = w.Service.Spreadsheets.BatchUpdate(w.SpreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to trim rows from %q: %w", w.SheetTitle, err)
+	}
+	return nil
+}
+
+// ColumnLetter converts a 0-indexed column number into its A1 notation
+// column letters (0 -> "A", 25 -> "Z", 26 -> "AA", ...).
+func ColumnLetter(index int) string {
+	letters := ""
+	index++
+	for index > 0 {
+		index--
+		letters = string(rune('A'+index%26)) + letters
+		index /= 26
+	}
+	return letters
+}
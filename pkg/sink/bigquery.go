@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/googleapi"
+)
+
+// BigQuerySink streams rows into a BigQuery table, evolving its schema to
+// add any newly observed flattened keys as nullable STRING columns.
+type BigQuerySink struct {
+	Client  *bigquery.Client
+	Dataset string
+	Table   string
+}
+
+func (s *BigQuerySink) Write(ctx context.Context, header []string, rows []map[string]string) error {
+	table := s.Client.Dataset(s.Dataset).Table(s.Table)
+	if err := s.ensureSchema(ctx, table, header); err != nil {
+		return err
+	}
+
+	savers := make([]*rowSaver, len(rows))
+	for i, row := range rows {
+		savers[i] = &rowSaver{header: header, row: row}
+	}
+
+	if err := table.Inserter().Put(ctx, savers); err != nil {
+		return fmt.Errorf("failed to stream rows into BigQuery table %s.%s: %w", s.Dataset, s.Table, err)
+	}
+	return nil
+}
+
+// ensureSchema creates the table with a STRING column per header entry if it
+// doesn't exist yet, or adds any columns not already present in its schema.
+func (s *BigQuerySink) ensureSchema(ctx context.Context, table *bigquery.Table, header []string) error {
+	meta, err := table.Metadata(ctx)
+	if err != nil {
+		if !isNotFound(err) {
+			return fmt.Errorf("failed to read BigQuery table metadata: %w", err)
+		}
+		schema := make(bigquery.Schema, len(header))
+		for i, key := range header {
+			schema[i] = &bigquery.FieldSchema{Name: key, Type: bigquery.StringFieldType}
+		}
+		if err := table.Create(ctx, &bigquery.TableMetadata{Schema: schema}); err != nil {
+			return fmt.Errorf("failed to create BigQuery table %s.%s: %w", s.Dataset, s.Table, err)
+		}
+		return nil
+	}
+
+	existing := make(map[string]bool, len(meta.Schema))
+	for _, f := range meta.Schema {
+		existing[f.Name] = true
+	}
+
+	schema := meta.Schema
+	extended := false
+	for _, key := range header {
+		if !existing[key] {
+			schema = append(schema, &bigquery.FieldSchema{Name: key, Type: bigquery.StringFieldType})
+			extended = true
+		}
+	}
+	if !extended {
+		return nil
+	}
+
+	if _, err := table.Update(ctx, bigquery.TableMetadataToUpdate{Schema: schema}, meta.ETag); err != nil {
+		return fmt.Errorf("failed to evolve BigQuery schema for %s.%s: %w", s.Dataset, s.Table, err)
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// rowSaver adapts a flattened cluster record to bigquery.ValueSaver, using
+// header to pick a stable column order.
+type rowSaver struct {
+	header []string
+	row    map[string]string
+}
+
+func (r *rowSaver) Save() (map[string]bigquery.Value, string, error) {
+	values := make(map[string]bigquery.Value, len(r.header))
+	for _, key := range r.header {
+		values[key] = r.row[key]
+	}
+	return values, "", nil
+}
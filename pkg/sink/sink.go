@@ -0,0 +1,99 @@
+// Package sink abstracts "write these flattened cluster rows somewhere"
+// behind a common interface, so the polling and flattening logic in main
+// doesn't need to duplicate itself for every destination the tool supports.
+package sink
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	sheetshelper "github.com/rhpds/cluster-status-updater-sheet/pkg/sheets"
+)
+
+// Sink writes one run's worth of flattened cluster rows, sharing a common
+// column header, to some destination.
+type Sink interface {
+	Write(ctx context.Context, header []string, rows []map[string]string) error
+}
+
+// CSVSink writes rows to a local CSV file at Path, overwriting it each run.
+type CSVSink struct {
+	Path string
+}
+
+func (s *CSVSink) Write(ctx context.Context, header []string, rows []map[string]string) error {
+	f, err := os.Create(s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file %q: %w", s.Path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, key := range header {
+			record[i] = row[key]
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// JSONLSink writes one JSON object per line to a local file at Path,
+// overwriting it each run.
+type JSONLSink struct {
+	Path string
+}
+
+func (s *JSONLSink) Write(ctx context.Context, header []string, rows []map[string]string) error {
+	f, err := os.Create(s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON lines file %q: %w", s.Path, err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("failed to write JSON line: %w", err)
+		}
+	}
+	return nil
+}
+
+// SheetsSink writes rows to a Google Sheets tab via a sheetshelper.SheetWriter,
+// clearing ClearRange first. This is the original full_data behavior,
+// reachable as one Sink implementation among several.
+type SheetsSink struct {
+	Writer     *sheetshelper.SheetWriter
+	ClearRange string
+}
+
+func (s *SheetsSink) Write(ctx context.Context, header []string, rows []map[string]string) error {
+	table := make([][]interface{}, 0, len(rows)+1)
+
+	headerRow := make([]interface{}, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	table = append(table, headerRow)
+
+	for _, row := range rows {
+		record := make([]interface{}, len(header))
+		for i, key := range header {
+			record[i] = row[key]
+		}
+		table = append(table, record)
+	}
+
+	return s.Writer.BatchAppendCells(ctx, s.ClearRange, table)
+}